@@ -0,0 +1,176 @@
+package restful
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+
+// Codec encodes and decodes bodies for one Content-Type, e.g. JSON,
+// YAML or protobuf. Register one with RegisterCodec.
+type Codec interface {
+	Encode(obj interface{}) ([]byte, error)
+	Decode(data []byte, dst interface{}) (error)
+}
+
+
+// Validator is implemented by types that can check their own
+// invariants once Request.Bind has populated them.
+type Validator interface {
+	Validate() (error)
+}
+
+
+var codecsMutex sync.RWMutex
+var codecs = map[string]Codec{}
+
+
+func init() {
+	RegisterCodec(APPLICATION_JSON, jsonCodec{})
+}
+
+
+// RegisterCodec makes c available for contentType, both to decode
+// incoming bodies (Request.Bind) and to encode responses (Marshal).
+// Call it from an init() to plug in msgpack, protobuf, and so on.
+func RegisterCodec(contentType string, codec Codec) {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+	codecs[contentType] = codec
+}
+
+
+func codecFor(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+	codec, ok := codecs[mediaType]
+	return codec, ok
+}
+
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(obj interface{}) ([]byte, error) {
+	return json.Marshal(obj)
+}
+
+func (jsonCodec) Decode(data []byte, dst interface{}) (error) {
+	return json.Unmarshal(data, dst)
+}
+
+
+// Bind reads the request body, decodes it into dst with the codec
+// matching Content-Type (defaulting to JSON when none is set) and, if
+// dst implements Validator, runs Validate() on it. It replaces the
+// older JsonDecoder() for controllers that want content negotiation.
+func (request Request) Bind(dst interface{}) (err error) {
+	contentType := request.HttpRequest.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = APPLICATION_JSON
+	}
+
+	codec, ok := codecFor(contentType)
+	if !ok {
+		return fmt.Errorf("restful: no codec registered for %q", contentType)
+	}
+
+	body, err := ioutil.ReadAll(request.HttpRequest.Body)
+	if err != nil {
+		return err
+	}
+
+	if err = codec.Decode(body, dst); err != nil {
+		return err
+	}
+
+	if validator, ok := dst.(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+
+type acceptEntry struct {
+	mediaType string
+	quality float64
+}
+
+
+// parseAccept reads an Accept header into its media types ordered by
+// quality (q=) value, highest first; entries with equal or missing q
+// (which defaults to 1) keep the order they appeared in.
+func parseAccept(accept string) ([]acceptEntry) {
+	var entries []acceptEntry
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.TrimSpace(params[0])
+		if mediaType == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if value := strings.TrimPrefix(param, "q="); value != param {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+
+		entries = append(entries, acceptEntry{mediaType: mediaType, quality: quality})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].quality > entries[j].quality
+	})
+	return entries
+}
+
+
+func firstAcceptable(accept string) (string) {
+	for _, entry := range parseAccept(accept) {
+		if _, ok := codecFor(entry.mediaType); ok {
+			return entry.mediaType
+		}
+	}
+	return ""
+}
+
+
+// Marshal encodes obj with the codec matching the request's Accept
+// header, defaulting to JSON when Accept is absent, "*/*", or names no
+// registered codec. It replaces Json(obj) for controllers that want to
+// honor content negotiation; Json itself is kept for compatibility.
+func Marshal(request Request, obj interface{}) (response Response) {
+	contentType := ""
+	if accept := request.HttpRequest.Header.Get("Accept"); accept != "" && accept != "*/*" {
+		contentType = firstAcceptable(accept)
+	}
+	if contentType == "" {
+		contentType = APPLICATION_JSON
+	}
+
+	codec, _ := codecFor(contentType)
+	data, err := codec.Encode(obj)
+	if err != nil {
+		return InternalServerError(Problem{Detail: err.Error()})
+	}
+
+	return Response{
+		ContentType: contentType,
+		Code: ScOK,
+		Data: data,
+	}
+}
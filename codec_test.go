@@ -0,0 +1,83 @@
+package restful
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+
+type signupBody struct {
+	Name string `json:"name"`
+}
+
+func (body signupBody) Validate() (error) {
+	if body.Name == "" {
+		return &ValidationError{Fields: map[string]string{"name": "required"}}
+	}
+	return nil
+}
+
+
+func TestBindDecodesAndValidates(t *testing.T) {
+	httpRequest := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"ada"}`))
+	httpRequest.Header.Set("Content-Type", APPLICATION_JSON)
+	request := Request{HttpRequest: httpRequest}
+
+	var body signupBody
+	if err := request.Bind(&body); err != nil {
+		t.Fatalf("Bind returned an error: %v", err)
+	}
+	if body.Name != "ada" {
+		t.Errorf("Name = %q, want %q", body.Name, "ada")
+	}
+}
+
+
+func TestBindRunsValidator(t *testing.T) {
+	httpRequest := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":""}`))
+	httpRequest.Header.Set("Content-Type", APPLICATION_JSON)
+	request := Request{HttpRequest: httpRequest}
+
+	var body signupBody
+	err := request.Bind(&body)
+
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("Bind error = %v, want a *ValidationError", err)
+	}
+	if validation.Fields["name"] != "required" {
+		t.Errorf("Fields[\"name\"] = %q, want %q", validation.Fields["name"], "required")
+	}
+}
+
+
+func TestFirstAcceptableHonorsQuality(t *testing.T) {
+	RegisterCodec("application/xml", jsonCodec{})
+	defer func() {
+		codecsMutex.Lock()
+		delete(codecs, "application/xml")
+		codecsMutex.Unlock()
+	}()
+
+	got := firstAcceptable("application/xml;q=0.9, application/json;q=0.1")
+	if got != "application/xml" {
+		t.Errorf("firstAcceptable() = %q, want %q", got, "application/xml")
+	}
+}
+
+
+func TestMarshalDefaultsToJSON(t *testing.T) {
+	httpRequest := httptest.NewRequest("GET", "/", nil)
+	request := Request{HttpRequest: httpRequest}
+
+	response := Marshal(request, map[string]string{"hello": "world"})
+
+	if response.ContentType != APPLICATION_JSON {
+		t.Errorf("ContentType = %q, want %q", response.ContentType, APPLICATION_JSON)
+	}
+	if !strings.Contains(string(response.Data), `"hello":"world"`) {
+		t.Errorf("Data = %s, want it to contain the encoded field", response.Data)
+	}
+}
@@ -0,0 +1,109 @@
+package restful
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+)
+
+
+const GZIP = "gzip"
+const DEFLATE = "deflate"
+
+
+func negotiateEncoding(acceptEncoding string) (string) {
+	for _, encoding := range strings.Split(acceptEncoding, ",") {
+		switch strings.TrimSpace(strings.SplitN(encoding, ";", 2)[0]) {
+		case GZIP:
+			return GZIP
+		case DEFLATE:
+			return DEFLATE
+		}
+	}
+	return ""
+}
+
+
+func newCompressWriter(encoding string, w io.Writer) (io.WriteCloser) {
+	if encoding == GZIP {
+		return gzip.NewWriter(w)
+	}
+	// DefaultCompression is always a valid level, so this can't fail.
+	fw, err := flate.NewWriter(w, flate.DefaultCompression)
+	if err != nil {
+		panic(err)
+	}
+	return fw
+}
+
+
+// Compress transparently gzip/deflate-encodes the response body
+// according to the request's Accept-Encoding header. It works for
+// buffered (Data) responses as well as streaming ones, wrapping the
+// writer handed to Response.Stream so long-lived feeds stay compressed.
+func Compress(next Handler) (Handler) {
+	return func(request Request) (Response) {
+		response := next(request)
+
+		encoding := negotiateEncoding(request.HttpRequest.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			return response
+		}
+
+		if response.Problem != nil {
+			// SendResponse would normally materialize Problem into Data
+			// itself, but that happens after middleware runs, which
+			// would otherwise leave the switch below with nothing to
+			// compress while still claiming Content-Encoding.
+			if response.Problem.RequestID == "" {
+				response.Problem.RequestID = request.RequestID
+			}
+			response.ContentType = PROBLEM_JSON
+			response.Data = response.Problem.marshal()
+			response.Problem = nil
+		}
+
+		if response.Headers == nil {
+			response.Headers = map[string]string{}
+		}
+		response.Headers["Content-Encoding"] = encoding
+		response.Headers["Vary"] = "Accept-Encoding"
+
+		switch {
+		case response.Stream != nil:
+			stream := response.Stream
+			response.Stream = func(ctx context.Context, w io.Writer) (error) {
+				compressed := newCompressWriter(encoding, w)
+				if err := stream(ctx, compressed); err != nil {
+					compressed.Close()
+					return err
+				}
+				return compressed.Close()
+			}
+
+		case response.Reader != nil:
+			reader := response.Reader
+			response.Reader = nil
+			response.Stream = func(ctx context.Context, w io.Writer) (error) {
+				compressed := newCompressWriter(encoding, w)
+				if _, err := io.Copy(compressed, reader); err != nil {
+					compressed.Close()
+					return err
+				}
+				return compressed.Close()
+			}
+
+		case len(response.Data) != 0:
+			var buf bytes.Buffer
+			compressed := newCompressWriter(encoding, &buf)
+			compressed.Write(response.Data)
+			compressed.Close()
+			response.Data = buf.Bytes()
+		}
+
+		return response
+	}
+}
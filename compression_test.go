@@ -0,0 +1,41 @@
+package restful
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+
+func TestCompressProblemResponse(t *testing.T) {
+	handler := Compress(func(request Request) (Response) {
+		return NotFound()
+	})
+
+	httpRequest := httptest.NewRequest("GET", "/missing", nil)
+	httpRequest.Header.Set("Accept-Encoding", "gzip")
+	response := handler(Request{RequestID: "test", HttpRequest: httpRequest})
+
+	if response.Headers["Content-Encoding"] != GZIP {
+		t.Fatalf("Content-Encoding = %q, want %q", response.Headers["Content-Encoding"], GZIP)
+	}
+	if response.Problem != nil {
+		t.Fatalf("Problem should have been materialized into Data, still set: %+v", response.Problem)
+	}
+
+	reader, err := gzip.NewReader(strings.NewReader(string(response.Data)))
+	if err != nil {
+		t.Fatalf("response.Data is not valid gzip: %v", err)
+	}
+	defer reader.Close()
+
+	decoded, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to decompress response.Data: %v", err)
+	}
+	if !strings.Contains(string(decoded), `"status":404`) {
+		t.Errorf("decompressed body = %q, want it to contain the 404 problem document", decoded)
+	}
+}
@@ -0,0 +1,99 @@
+package restful
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+
+// Handler is the logical counterpart of http.HandlerFunc: it takes a
+// Request and produces a Response, without knowing about the wire
+// connection. Controllers are dispatched through a Handler built from
+// the service's and controller's middleware.
+type Handler func(request Request) (Response)
+
+
+// Middleware wraps a Handler with additional behaviour (auth, logging,
+// compression, ...) and decides whether/how to call the next one.
+type Middleware func(next Handler) (Handler)
+
+
+// Use registers middleware that runs for every controller registered
+// on the service after this call, outermost first. Routes registered
+// before the call keep the chain they were built with.
+func (service *Service) Use(middleware ...Middleware) {
+	service.middleware = append(service.middleware, middleware...)
+}
+
+
+// chain builds the final Handler for one route: service-wide middleware
+// runs first, then the middleware passed to Register/RegisterAction,
+// then handler itself.
+func (service *Service) chain(middleware []Middleware, handler Handler) (Handler) {
+	all := make([]Middleware, 0, len(service.middleware) + len(middleware))
+	all = append(all, service.middleware...)
+	all = append(all, middleware...)
+
+	for i := len(all) - 1; i >= 0; i-- {
+		handler = all[i](handler)
+	}
+	return handler
+}
+
+
+// RequireHeader rejects requests whose path matches pathPattern unless
+// the named header is present and matches valuePattern, answering
+// failStatus (e.g. ScBadRequest or ScUnauthorized) as a problem+json
+// document otherwise.
+func RequireHeader(pathPattern string, name string, valuePattern string, failStatus int) (Middleware) {
+	path := regexp.MustCompile(pathPattern)
+	value := regexp.MustCompile(valuePattern)
+
+	return func(next Handler) (Handler) {
+		return func(request Request) (Response) {
+			if path.MatchString(request.HttpRequest.URL.Path) {
+				if !value.MatchString(request.HttpRequest.Header.Get(name)) {
+					return problemResponse(failStatus, http.StatusText(failStatus), nil)
+				}
+			}
+			return next(request)
+		}
+	}
+}
+
+
+// JWTAuth validates a "Authorization: Bearer <token>" header against
+// secret and, on success, exposes the parsed claims through
+// Request.Auth. Missing or invalid tokens get a ScUnauthorized
+// problem+json document.
+func JWTAuth(secret []byte) (Middleware) {
+	return func(next Handler) (Handler) {
+		return func(request Request) (Response) {
+			header := request.HttpRequest.Header.Get("Authorization")
+			if !strings.HasPrefix(header, "Bearer ") {
+				return problemResponse(ScUnauthorized, "Unauthorized", nil)
+			}
+
+			tokenString := strings.TrimPrefix(header, "Bearer ")
+			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+				// Reject anything but HMAC: otherwise a token signed
+				// with "alg": "none" or an RSA public key an attacker
+				// controls could be accepted against our own secret.
+				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+					return nil, fmt.Errorf("restful: unexpected signing method %v", token.Header["alg"])
+				}
+				return secret, nil
+			})
+			if err != nil || !token.Valid {
+				return problemResponse(ScUnauthorized, "Unauthorized", nil)
+			}
+
+			request.Auth = token.Claims
+			return next(request)
+		}
+	}
+}
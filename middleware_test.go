@@ -0,0 +1,60 @@
+package restful
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"testing"
+)
+
+
+func rawJSON(fields string) (string) {
+	return base64.RawURLEncoding.EncodeToString([]byte(fields))
+}
+
+
+func TestJWTAuthRejectsNoneAlgorithm(t *testing.T) {
+	// alg: none with no signature must not be accepted, even though
+	// jwt.Parse would otherwise consider it "valid" against any key.
+	token := rawJSON(`{"alg":"none","typ":"JWT"}`) + "." + rawJSON(`{"sub":"attacker"}`) + "."
+
+	handlerCalled := false
+	middleware := JWTAuth([]byte("secret"))
+	handler := middleware(func(request Request) (Response) {
+		handlerCalled = true
+		return Response{Code: ScOK}
+	})
+
+	httpRequest := httptest.NewRequest("GET", "/", nil)
+	httpRequest.Header.Set("Authorization", "Bearer "+token)
+	request := Request{HttpRequest: httpRequest}
+
+	response := handler(request)
+
+	if response.Code != ScUnauthorized {
+		t.Errorf("Code = %d, want %d", response.Code, ScUnauthorized)
+	}
+	if handlerCalled {
+		t.Errorf("next handler ran for an alg=none token")
+	}
+	if response.Problem == nil {
+		t.Errorf("expected a problem+json body, got an empty one")
+	}
+}
+
+
+func TestRequireHeaderEmitsProblem(t *testing.T) {
+	middleware := RequireHeader("^/admin/", "X-Api-Key", "^secret$", ScBadRequest)
+	handler := middleware(func(request Request) (Response) {
+		return Response{Code: ScOK}
+	})
+
+	httpRequest := httptest.NewRequest("GET", "/admin/users", nil)
+	response := handler(Request{HttpRequest: httpRequest})
+
+	if response.Code != ScBadRequest {
+		t.Errorf("Code = %d, want %d", response.Code, ScBadRequest)
+	}
+	if response.Problem == nil {
+		t.Errorf("expected a problem+json body, got an empty one")
+	}
+}
@@ -0,0 +1,191 @@
+package restful
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+
+// MethodSchema declares the request/response shape of one controller
+// method for OpenAPI generation: In/Out are zero-value Go structs to
+// reflect over, Status is the success status code (defaults to ScOK).
+type MethodSchema struct {
+	In interface{}
+	Out interface{}
+	Status int
+}
+
+
+// SchemaController is implemented by controllers that want their
+// request/response bodies reflected into Service.OpenAPI(). Keys are
+// IController method names: "Create", "Get", "Update", "Delete", "List".
+type SchemaController interface {
+	Schema() map[string]MethodSchema
+}
+
+
+type openapiResource struct {
+	path string
+	controller IController
+}
+
+
+func (service *Service) trackResource(path string, controller IController) {
+	service.resources = append(service.resources, openapiResource{path: path, controller: controller})
+}
+
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+func pathParameters(path string) ([]map[string]interface{}) {
+	var parameters []map[string]interface{}
+	for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name": match[1],
+			"in": "path",
+			"required": true,
+			"schema": map[string]interface{}{"type": "string"},
+		})
+	}
+	return parameters
+}
+
+
+func reflectKind(kind reflect.Kind) (string) {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+
+func reflectSchema(obj interface{}) (map[string]interface{}) {
+	t := reflect.TypeOf(obj)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		kind := reflect.Invalid
+		if t != nil {
+			kind = t.Kind()
+		}
+		return map[string]interface{}{"type": reflectKind(kind)}
+	}
+
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			name = strings.SplitN(tag, ",", 2)[0]
+		}
+		properties[name] = map[string]interface{}{"type": reflectKind(field.Type.Kind())}
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+
+func operationFor(verb string, schemas map[string]MethodSchema) (map[string]interface{}) {
+	operation := map[string]interface{}{"operationId": verb}
+
+	schema, ok := schemas[verb]
+	if !ok {
+		operation["responses"] = map[string]interface{}{"200": map[string]interface{}{"description": "OK"}}
+		return operation
+	}
+
+	if schema.In != nil {
+		operation["requestBody"] = map[string]interface{}{
+			"content": map[string]interface{}{
+				APPLICATION_JSON: map[string]interface{}{"schema": reflectSchema(schema.In)},
+			},
+		}
+	}
+
+	status := schema.Status
+	if status == 0 {
+		status = ScOK
+	}
+
+	response := map[string]interface{}{"description": "OK"}
+	if schema.Out != nil {
+		response["content"] = map[string]interface{}{
+			APPLICATION_JSON: map[string]interface{}{"schema": reflectSchema(schema.Out)},
+		}
+	}
+	operation["responses"] = map[string]interface{}{fmt.Sprintf("%d", status): response}
+
+	return operation
+}
+
+
+// OpenAPI walks every resource registered through Register so far and
+// reflects each SchemaController's declared types into an OpenAPI 3
+// document. Controllers that don't implement SchemaController still
+// get their routes listed, with bare 200 responses.
+func (service *Service) OpenAPI(title string, version string) (map[string]interface{}) {
+	paths := map[string]interface{}{}
+
+	for _, resource := range service.resources {
+		schemas := map[string]MethodSchema{}
+		if schemaController, ok := resource.controller.(SchemaController); ok {
+			schemas = schemaController.Schema()
+		}
+
+		collectionPath := resource.path
+		itemPath := resource.path + placeholder(UUID)
+
+		paths[collectionPath] = map[string]interface{}{
+			"get": operationFor("List", schemas),
+			"post": operationFor("Create", schemas),
+		}
+		paths[itemPath] = map[string]interface{}{
+			"parameters": pathParameters(itemPath),
+			"get": operationFor("Get", schemas),
+			"put": operationFor("Update", schemas),
+			"delete": operationFor("Delete", schemas),
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{"title": title, "version": version},
+		"paths": paths,
+	}
+}
+
+
+// MountOpenAPI serves service.OpenAPI(title, version) as JSON at path,
+// regenerated on every request so it always matches what is currently
+// registered.
+func (service *Service) MountOpenAPI(path string, title string, version string) {
+	service.Router.HandleFunc(path, func(rw http.ResponseWriter, httpRequest *http.Request) {
+		data, err := json.Marshal(service.OpenAPI(title, version))
+		if err != nil {
+			rw.WriteHeader(ScInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", APPLICATION_JSON)
+		rw.WriteHeader(ScOK)
+		rw.Write(data)
+	})
+}
@@ -0,0 +1,86 @@
+package restful
+
+import (
+	"testing"
+)
+
+
+type widget struct {
+	Name string `json:"name"`
+	Count int `json:"count"`
+	unexported string
+}
+
+
+func TestReflectSchema(t *testing.T) {
+	schema := reflectSchema(widget{})
+
+	if schema["type"] != "object" {
+		t.Fatalf("type = %v, want \"object\"", schema["type"])
+	}
+
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("properties is not a map: %v", schema["properties"])
+	}
+
+	if _, ok := properties["unexported"]; ok {
+		t.Errorf("unexported field leaked into the schema")
+	}
+
+	name, ok := properties["name"].(map[string]interface{})
+	if !ok || name["type"] != "string" {
+		t.Errorf("properties[\"name\"] = %v, want type string", properties["name"])
+	}
+
+	count, ok := properties["count"].(map[string]interface{})
+	if !ok || count["type"] != "integer" {
+		t.Errorf("properties[\"count\"] = %v, want type integer", properties["count"])
+	}
+}
+
+
+type widgetsController struct {
+	Controller
+}
+
+func (*widgetsController) Schema() map[string]MethodSchema {
+	return map[string]MethodSchema{
+		"Create": {In: widget{}, Out: widget{}, Status: ScCreated},
+		"List": {Out: []widget{}},
+	}
+}
+
+
+func TestOpenAPIWalksRegisteredResources(t *testing.T) {
+	service := NewService(nil)
+	service.Register(&widgetsController{Controller{Url: "widgets"}})
+
+	document := service.OpenAPI("Widgets API", "1.0.0")
+
+	paths, ok := document["paths"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("paths is not a map: %v", document["paths"])
+	}
+
+	collection, ok := paths["widgets/"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("missing widgets/ path: %v", paths)
+	}
+
+	post, ok := collection["post"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("missing POST operation: %v", collection)
+	}
+	if post["operationId"] != "Create" {
+		t.Errorf("operationId = %v, want \"Create\"", post["operationId"])
+	}
+
+	item, ok := paths["widgets/{uuid}"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("missing widgets/{uuid} path: %v", paths)
+	}
+	if _, ok := item["get"]; !ok {
+		t.Errorf("missing GET operation on the item path")
+	}
+}
@@ -0,0 +1,128 @@
+package restful
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+
+const PROBLEM_JSON = "application/problem+json"
+
+
+// Problem is a RFC 7807 "problem details" document. Extensions holds
+// any additional members beyond the ones RFC 7807 names; they are
+// merged into the top-level JSON object alongside type/title/etc.
+type Problem struct {
+	Type string
+	Title string
+	Status int
+	Detail string
+	Instance string
+	RequestID string
+	Extensions map[string]interface{}
+}
+
+
+func (problem Problem) marshal() ([]byte) {
+	doc := make(map[string]interface{}, len(problem.Extensions) + 6)
+	for key, value := range problem.Extensions {
+		doc[key] = value
+	}
+	if problem.Type != "" {
+		doc["type"] = problem.Type
+	}
+	if problem.Title != "" {
+		doc["title"] = problem.Title
+	}
+	if problem.Status != 0 {
+		doc["status"] = problem.Status
+	}
+	if problem.Detail != "" {
+		doc["detail"] = problem.Detail
+	}
+	if problem.Instance != "" {
+		doc["instance"] = problem.Instance
+	}
+	if problem.RequestID != "" {
+		doc["request_id"] = problem.RequestID
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		// doc only ever holds JSON-safe values handed in by the
+		// caller, but fall back to something valid just in case.
+		title, _ := json.Marshal(problem.Title)
+		return []byte(`{"title":` + string(title) + `}`)
+	}
+	return data
+}
+
+
+// problemResponse fills in Status/Title defaults and wraps the result
+// in a Response; problems is the variadic argument the exported error
+// constructors (BadRequest, NotFound, ...) pass through unchanged.
+func problemResponse(status int, defaultTitle string, problems []Problem) (response Response) {
+	var problem Problem
+	if len(problems) > 0 {
+		problem = problems[0]
+	}
+	if problem.Status == 0 {
+		problem.Status = status
+	}
+	if problem.Title == "" {
+		problem.Title = defaultTitle
+	}
+
+	return Response{Code: status, Problem: &problem}
+}
+
+
+/* Sentinel errors controllers can wrap or compare against with errors.Is */
+var ErrNotFound = errors.New("restful: not found")
+var ErrConflict = errors.New("restful: conflict")
+var ErrBadRequest = errors.New("restful: bad request")
+
+
+// ValidationError reports per-field failures; a Validator returns it
+// from Validate() and Request.Bind hands it back to the caller
+// unchanged. ProblemFromError turns it into an
+// UnprocessableEntity whose Problem.Extensions["errors"] is Fields.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (err *ValidationError) Error() (string) {
+	return fmt.Sprintf("restful: validation failed for %d field(s)", len(err.Fields))
+}
+
+
+// ProblemFromError maps err to the matching error Response: a
+// *ValidationError becomes UnprocessableEntity with its fields under a
+// single "errors" extension, the sentinel errors above become their
+// matching 4xx, and
+// anything else becomes InternalServerError.
+func ProblemFromError(err error) (response Response) {
+	var validation *ValidationError
+	if errors.As(err, &validation) {
+		fields := make(map[string]interface{}, len(validation.Fields))
+		for field, message := range validation.Fields {
+			fields[field] = message
+		}
+		return UnprocessableEntity(Problem{
+			Detail: "one or more fields failed validation",
+			Extensions: map[string]interface{}{"errors": fields},
+		})
+	}
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return NotFound(Problem{Detail: err.Error()})
+	case errors.Is(err, ErrConflict):
+		return Conflict(Problem{Detail: err.Error()})
+	case errors.Is(err, ErrBadRequest):
+		return BadRequest(Problem{Detail: err.Error()})
+	default:
+		return InternalServerError(Problem{Detail: err.Error()})
+	}
+}
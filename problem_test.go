@@ -0,0 +1,48 @@
+package restful
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+
+func TestProblemFromErrorMapsSentinels(t *testing.T) {
+	cases := []struct {
+		name string
+		err error
+		wantCode int
+	}{
+		{"not found", ErrNotFound, ScNotFound},
+		{"conflict", ErrConflict, ScConflict},
+		{"bad request", ErrBadRequest, ScBadRequest},
+		{"unknown", errors.New("boom"), ScInternalServerError},
+		{
+			"validation",
+			&ValidationError{Fields: map[string]string{"email": "invalid"}},
+			ScUnprocessableEntity,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			response := ProblemFromError(testCase.err)
+			if response.Code != testCase.wantCode {
+				t.Errorf("Code = %d, want %d", response.Code, testCase.wantCode)
+			}
+			if response.Problem == nil {
+				t.Fatalf("expected a Problem, got nil")
+			}
+		})
+	}
+}
+
+
+func TestProblemFromErrorWrappedSentinel(t *testing.T) {
+	err := fmt.Errorf("listing offices: %w", ErrNotFound)
+
+	response := ProblemFromError(err)
+	if response.Code != ScNotFound {
+		t.Errorf("Code = %d, want %d", response.Code, ScNotFound)
+	}
+}
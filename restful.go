@@ -1,7 +1,9 @@
 package restful
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
         "encoding/json"
         "net/http"
@@ -33,6 +35,7 @@ const (
         ScMethodNotAllowed = 405
         ScConflict = 409
 	ScUnprocessableEntity = 422
+        ScUnauthorized = 401
 )
 
 
@@ -50,6 +53,9 @@ const PLAIN_TEXT = "text/plain"
 type Service struct {
 	Router *mux.Router
 	Context interface{}
+	middleware []Middleware
+	server *http.Server
+	resources []openapiResource
 }
 
 
@@ -57,6 +63,10 @@ type Request struct {
 	RequestID string
 	Service *Service
 	HttpRequest *http.Request
+
+	// Auth carries whatever an authentication middleware (e.g. JWTAuth)
+	// extracted from the request; nil if none ran or it didn't set one.
+	Auth interface{}
 }
 
 
@@ -113,6 +123,25 @@ type Response struct {
 	Uuid string
 	Headers map[string]string
 	Data []byte
+
+	// Reader, when set, is copied to the client instead of Data without
+	// being buffered in memory first.
+	Reader io.Reader
+
+	// Stream, when set, takes over the response body. It is handed the
+	// request's context and the http.ResponseWriter (which also
+	// implements http.Flusher), and is responsible for writing and
+	// flushing its own chunks. It must select on ctx.Done() (or treat
+	// write errors as fatal) to stop once the client disconnects;
+	// SendResponse runs it synchronously and will not return until it
+	// does.
+	Stream func(ctx context.Context, w io.Writer) error
+
+	// Problem, when set, is serialized as application/problem+json by
+	// SendResponse, which also stamps its RequestID from the Request.
+	// Use BadRequest/NotFound/... to build one instead of setting it
+	// directly.
+	Problem *Problem
 }
 
 
@@ -136,49 +165,36 @@ func Deleted() (response Response) {
 	return response
 }
 
-func BadRequest() (response Response) {
-	response = Response{
-		Code: ScBadRequest}
-	return response
+// BadRequest answers ScBadRequest as a problem+json document. Pass a
+// Problem to add detail; called with no arguments it still emits a
+// minimal, valid one.
+func BadRequest(problem ...Problem) (response Response) {
+	return problemResponse(ScBadRequest, "Bad Request", problem)
 }
 
 
-func InternalServerError(errinfo string) (response Response) {
-	response = Response{
-		Code: ScInternalServerError,
-		ContentType: PLAIN_TEXT,
-		Data: []byte(errinfo)}
-	return response
+func InternalServerError(problem ...Problem) (response Response) {
+	return problemResponse(ScInternalServerError, "Internal Server Error", problem)
 }
 
 
-func NotFound() (response Response) {
-	response = Response{
-		Code: ScNotFound}
-	return response
+func NotFound(problem ...Problem) (response Response) {
+	return problemResponse(ScNotFound, "Not Found", problem)
 }
 
 
-func Conflict() (response Response) {
-	response = Response{
-		Code: ScConflict}
-	return response
+func Conflict(problem ...Problem) (response Response) {
+	return problemResponse(ScConflict, "Conflict", problem)
 }
 
 
-func MethodNotAllowed() (response Response) {
-	response = Response{
-		Code: ScMethodNotAllowed,
-		Data: nil}
-	return response
+func MethodNotAllowed(problem ...Problem) (response Response) {
+	return problemResponse(ScMethodNotAllowed, "Method Not Allowed", problem)
 }
 
 
-func UnprocessableEntity(message string) (response Response) {
-	response = Response{
-		Code: ScUnprocessableEntity,
-		Data: []byte(message)}
-	return response
+func UnprocessableEntity(problem ...Problem) (response Response) {
+	return problemResponse(ScUnprocessableEntity, "Unprocessable Entity", problem)
 }
 
 
@@ -193,10 +209,12 @@ func Plain(answer string) (response Response) {
 }
 
 
+// Json always encodes obj as JSON. Prefer Marshal(request, obj) when
+// the controller should honor the client's Accept header instead.
 func Json(obj interface{}) (response Response) {
 	jsonContent, err := json.Marshal(obj)
 	if err != nil {
-		return InternalServerError(err.Error())
+		return InternalServerError(Problem{Detail: err.Error()})
 	} else {
 		response = Response{
 			ContentType: APPLICATION_JSON,
@@ -208,6 +226,63 @@ func Json(obj interface{}) (response Response) {
 }
 
 
+/* Server-sent events */
+
+const EVENT_STREAM = "text/event-stream"
+
+type Event struct {
+	ID string
+	Event string
+	Data string
+}
+
+func (event Event) write(w io.Writer) (err error) {
+	if event.ID != "" {
+		if _, err = fmt.Fprintf(w, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+	if event.Event != "" {
+		if _, err = fmt.Fprintf(w, "event: %s\n", event.Event); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", event.Data)
+	return err
+}
+
+// EventStream builds a Response that streams ch to the client as
+// server-sent events, flushing after every event so long-polling
+// feeds do not wait for the buffer to fill. It stops as soon as ctx
+// is done (the client disconnected) or ch is closed.
+func EventStream(ch <-chan Event) (response Response) {
+	response = Response{
+		ContentType: EVENT_STREAM,
+		Code: ScOK,
+		Stream: func(ctx context.Context, w io.Writer) error {
+			flusher, _ := w.(http.Flusher)
+			for {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case event, ok := <-ch:
+					if !ok {
+						return nil
+					}
+					if err := event.write(w); err != nil {
+						return err
+					}
+					if flusher != nil {
+						flusher.Flush()
+					}
+				}
+			}
+		},
+	}
+	return response
+}
+
+
 func (*Controller) Create(request Request) (Response) {
 	return MethodNotAllowed()
 }
@@ -248,7 +323,7 @@ func NewService(context interface{}) (service *Service) {
 	service = new(Service)
 	service.Router = mux.NewRouter()
 	service.Context = context
-	http.Handle("/", service.Router)
+	service.server = &http.Server{Handler: service.Router}
 	return service
 }
 
@@ -271,6 +346,14 @@ func logResponse(request Request, response Response) {
 func SendResponse(request Request, rw http.ResponseWriter, response Response) () {
 	logResponse(request, response)
 
+	if response.Problem != nil {
+		if response.Problem.RequestID == "" {
+			response.Problem.RequestID = request.RequestID
+		}
+		response.ContentType = PROBLEM_JSON
+		response.Data = response.Problem.marshal()
+	}
+
 	if response.ContentType != "" {
 		rw.Header().Set("Content-Type", response.ContentType)
 	}
@@ -280,6 +363,31 @@ func SendResponse(request Request, rw http.ResponseWriter, response Response) ()
 	if response.Uuid != "" {
 		rw.Header().Set("X-UUID", response.Uuid)
 	}
+	for name, value := range response.Headers {
+		rw.Header().Set(name, value)
+	}
+
+	if response.Stream != nil {
+		// net/http switches to Transfer-Encoding: chunked on its own
+		// once the handler writes without ever setting Content-Length.
+		rw.WriteHeader(response.Code)
+
+		// Run synchronously: Stream is handed the request's context so
+		// it can stop itself on client disconnect, and rw must not be
+		// touched after SendResponse returns.
+		if err := response.Stream(request.HttpRequest.Context(), rw); err != nil {
+			log.Println(request.RequestID, "stream error", err)
+		}
+		return
+	}
+
+	if response.Reader != nil {
+		rw.WriteHeader(response.Code)
+		if _, err := io.Copy(rw, response.Reader); err != nil {
+			log.Println(request.RequestID, "copy error", err)
+		}
+		return
+	}
 
 	rw.WriteHeader(response.Code)
 
@@ -289,78 +397,77 @@ func SendResponse(request Request, rw http.ResponseWriter, response Response) ()
 }
 
 
-func (service *Service) constructItemHandler(controller IController) http.HandlerFunc {
-        return func(rw http.ResponseWriter, httpRequest *http.Request) {
+func (service *Service) constructItemHandler(controller IController, middleware []Middleware) http.HandlerFunc {
+	handler := service.chain(middleware, func(request Request) Response {
+		switch request.HttpRequest.Method {
+		case "GET":
+			return controller.Get(request)
+		case "PUT":
+			return controller.Update(request)
+		case "DELETE":
+			return controller.Delete(request)
+		default:
+			return MethodNotAllowed()
+		}
+	})
 
+        return func(rw http.ResponseWriter, httpRequest *http.Request) {
 		request := initRequest(service, httpRequest)
 		logRequest(request)
 
-                method := httpRequest.Method
-
-		var response Response
-
-                switch method {
-                case "GET":
-                        response = controller.Get(request)
-                case "PUT":
-                        response = controller.Update(request)
-                case "DELETE":
-                        response = controller.Delete(request)
-		default:
-			response = MethodNotAllowed()
-                }
-
-		SendResponse(request, rw, response)
+		SendResponse(request, rw, handler(request))
         }
 }
 
 
-func (service *Service) constructCollectionHandler(controller IController) http.HandlerFunc {
-        return func(rw http.ResponseWriter, httpRequest *http.Request) {
+func (service *Service) constructCollectionHandler(controller IController, middleware []Middleware) http.HandlerFunc {
+	handler := service.chain(middleware, func(request Request) Response {
+		switch request.HttpRequest.Method {
+		case "GET":
+			return controller.List(request)
+		case "POST":
+			return controller.Create(request)
+		default:
+			return MethodNotAllowed()
+		}
+	})
 
+        return func(rw http.ResponseWriter, httpRequest *http.Request) {
 		request := initRequest(service, httpRequest)
 		logRequest(request)
 
-                method := httpRequest.Method
-
-		var response Response
-
-                switch method {
-                case "GET":
-                        response = controller.List(request)
-                case "POST":
-                        response = controller.Create(request)
-		default:
-			response = MethodNotAllowed()
-                }
-
-		SendResponse(request, rw, response)
+		SendResponse(request, rw, handler(request))
         }
 }
 
 
-func (service *Service) Register(controller IController) {
+func (service *Service) Register(controller IController, middleware ...Middleware) {
 	collectionPath := controller.RootUrl()
 	correctedPath := correctCollectionPath(collectionPath)
 	service.Router.HandleFunc(
 		correctedPath,
-		service.constructCollectionHandler(controller))
+		service.constructCollectionHandler(controller, middleware))
 
 	service.Router.HandleFunc(
 		correctedPath + "{uuid}",
-		service.constructItemHandler(controller))
+		service.constructItemHandler(controller, middleware))
+
+	service.trackResource(correctedPath, controller)
 }
 
-func (service *Service) RegisterAction(controller IController) {
+func (service *Service) RegisterAction(controller IController, middleware ...Middleware) {
 	collectionPath := controller.RootUrl()
 	correctedPath := correctCollectionPath(collectionPath) + "invoke"
 	service.Router.HandleFunc(
 		correctedPath,
-		service.constructCollectionHandler(controller))
+		service.constructCollectionHandler(controller, middleware))
 }
 
 func (service *Service) Forever(host string, port uint) {
 	address := fmt.Sprintf("%s:%d", host, port)
 	log.Println("Forever on", address)
-	http.ListenAndServe(address, nil)
+	service.server.Addr = address
+	if err := service.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
 }
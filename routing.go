@@ -0,0 +1,158 @@
+package restful
+
+import (
+	"net/http"
+	"strings"
+)
+
+
+// VerbController lets a controller expose arbitrary named actions
+// beyond the standard REST verbs. It is invoked through RegisterVerb
+// at both the collection level (/resource/invoke/{verb}) and the item
+// level (/resource/{uuid}/invoke/{verb}).
+type VerbController interface {
+	Invoke(verb string, request Request) (Response)
+}
+
+
+func singularize(name string) (string) {
+	if strings.HasSuffix(name, "s") {
+		return name[:len(name) - 1]
+	}
+	return name
+}
+
+
+type routeSegment struct {
+	collection string
+	varName string
+}
+
+
+// RouteBuilder accumulates a (possibly nested) resource path, e.g.
+// service.Resource("offices").Item().SubResource("clercs"). Call
+// Register/RegisterItemAction on the result to mount a controller.
+type RouteBuilder struct {
+	service *Service
+	segments []routeSegment
+}
+
+
+// Resource starts a RouteBuilder rooted at /name/.
+func (service *Service) Resource(name string) (*RouteBuilder) {
+	return &RouteBuilder{
+		service: service,
+		segments: []routeSegment{{collection: name}},
+	}
+}
+
+
+// Item marks the resource currently being built as addressable by a
+// "{uuid}" placeholder. It only matters when followed by SubResource:
+// SubResource renames this placeholder (e.g. to "office_uuid") so it
+// does not collide with the nested resource's own uuid.
+func (builder *RouteBuilder) Item() (*RouteBuilder) {
+	segments := append([]routeSegment{}, builder.segments...)
+	segments[len(segments) - 1].varName = UUID
+	return &RouteBuilder{service: builder.service, segments: segments}
+}
+
+
+// SubResource nests another collection under the current item, e.g.
+// offices/{office_uuid}/clercs/. Register it like a top-level resource;
+// the parent uuid is reachable through Request.Var("office_uuid").
+func (builder *RouteBuilder) SubResource(name string) (*RouteBuilder) {
+	segments := append([]routeSegment{}, builder.segments...)
+	parent := &segments[len(segments) - 1]
+	if parent.varName != "" {
+		parent.varName = singularize(parent.collection) + "_" + UUID
+	}
+	segments = append(segments, routeSegment{collection: name})
+	return &RouteBuilder{service: builder.service, segments: segments}
+}
+
+
+// collectionPath renders the accumulated segments, keeping every
+// ancestor's uuid placeholder but never the last segment's own one —
+// Register/RegisterItemAction add that explicitly.
+func (builder *RouteBuilder) collectionPath() (string) {
+	path := "/"
+	last := len(builder.segments) - 1
+	for i, segment := range builder.segments {
+		path = correctCollectionPath(path + segment.collection)
+		if i != last && segment.varName != "" {
+			path = path + placeholder(segment.varName) + "/"
+		}
+	}
+	return path
+}
+
+
+// Register mounts controller's collection and item routes at the path
+// this builder describes, exactly like Service.Register but nestable.
+func (builder *RouteBuilder) Register(controller IController, middleware ...Middleware) {
+	collectionPath := builder.collectionPath()
+	builder.service.Router.HandleFunc(
+		collectionPath,
+		builder.service.constructCollectionHandler(controller, middleware))
+
+	builder.service.Router.HandleFunc(
+		collectionPath + placeholder(UUID),
+		builder.service.constructItemHandler(controller, middleware))
+
+	builder.service.trackResource(collectionPath, controller)
+}
+
+
+// RegisterItemAction mounts handler at .../{uuid}/actions/name,
+// answering MethodNotAllowed for any HTTP method not in methods (an
+// empty methods allows all of them).
+func (builder *RouteBuilder) RegisterItemAction(name string, methods []string, handler Handler, middleware ...Middleware) {
+	allowed := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		allowed[method] = true
+	}
+
+	service := builder.service
+	dispatch := service.chain(middleware, handler)
+	route := builder.collectionPath() + placeholder(UUID) + "/actions/" + name
+
+	service.Router.HandleFunc(route, func(rw http.ResponseWriter, httpRequest *http.Request) {
+		request := initRequest(service, httpRequest)
+		logRequest(request)
+
+		var response Response
+		if len(allowed) == 0 || allowed[httpRequest.Method] {
+			response = dispatch(request)
+		} else {
+			response = MethodNotAllowed()
+		}
+		SendResponse(request, rw, response)
+	})
+}
+
+
+// RegisterVerb mounts controller's VerbController.Invoke at
+// /resource/invoke/{verb} and /resource/{uuid}/invoke/{verb}, letting
+// one controller expose many named actions instead of the single
+// hardcoded "invoke" of RegisterAction.
+func (service *Service) RegisterVerb(controller IController, middleware ...Middleware) {
+	verbController, ok := controller.(VerbController)
+	if !ok {
+		return
+	}
+
+	collectionPath := correctCollectionPath(controller.RootUrl())
+	handler := service.chain(middleware, func(request Request) (Response) {
+		return verbController.Invoke(request.Var("verb"), request)
+	})
+
+	dispatch := func(rw http.ResponseWriter, httpRequest *http.Request) {
+		request := initRequest(service, httpRequest)
+		logRequest(request)
+		SendResponse(request, rw, handler(request))
+	}
+
+	service.Router.HandleFunc(collectionPath + "invoke/" + placeholder("verb"), dispatch)
+	service.Router.HandleFunc(collectionPath + placeholder(UUID) + "/invoke/" + placeholder("verb"), dispatch)
+}
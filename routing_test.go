@@ -0,0 +1,41 @@
+package restful
+
+import (
+	"testing"
+)
+
+
+func TestRouteBuilderCollectionPath(t *testing.T) {
+	service := NewService(nil)
+
+	cases := []struct {
+		name string
+		builder *RouteBuilder
+		want string
+	}{
+		{
+			name: "top-level resource",
+			builder: service.Resource("offices"),
+			want: "/offices/",
+		},
+		{
+			name: "sub-resource",
+			builder: service.Resource("offices").Item().SubResource("clercs"),
+			want: "/offices/{office_uuid}/clercs/",
+		},
+		{
+			name: "sub-resource without Item() has no parent uuid placeholder",
+			builder: service.Resource("offices").SubResource("clercs"),
+			want: "/offices/clercs/",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := testCase.builder.collectionPath()
+			if got != testCase.want {
+				t.Errorf("collectionPath() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package restful
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+
+// ServeTLS is the HTTPS counterpart of Forever: it serves address
+// using the given certificate/key pair.
+func (service *Service) ServeTLS(host string, port uint, certFile string, keyFile string) {
+	address := fmt.Sprintf("%s:%d", host, port)
+	log.Println("Forever (TLS) on", address)
+	service.server.Addr = address
+	if err := service.server.ListenAndServeTLS(certFile, keyFile); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+
+// Serve runs the service on an already-opened listener instead of
+// binding its own, e.g. a net.Listener from net/http/httptest or one
+// shared between several Service instances in the same process.
+func (service *Service) Serve(listener net.Listener) {
+	log.Println("Forever on", listener.Addr())
+	if err := service.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+
+// Shutdown gracefully stops the service, letting in-flight requests
+// finish (or ctx expire) before returning. Forever/ServeTLS/Serve
+// return as soon as Shutdown begins, without waiting for it to finish.
+func (service *Service) Shutdown(ctx context.Context) (error) {
+	return service.server.Shutdown(ctx)
+}
+
+
+// ShutdownOnSignal calls Shutdown as soon as the process receives
+// SIGINT or SIGTERM, giving in-flight requests up to timeout to drain
+// from the moment the signal arrives (not from when this is called).
+// It returns immediately; the signal is handled in the background.
+func (service *Service) ShutdownOnSignal(timeout time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		log.Println("received", sig, "- shutting down")
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := service.Shutdown(ctx); err != nil {
+			log.Println("shutdown error", err)
+		}
+	}()
+}
@@ -0,0 +1,51 @@
+package restful
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+
+func TestShutdownOnSignalStopsServerOnSIGINT(t *testing.T) {
+	service := NewService(nil)
+	service.Router.HandleFunc("/", func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(ScOK)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- service.server.Serve(listener)
+	}()
+
+	// Arming the signal handler must not stop the server by itself: it
+	// keeps serving until a signal arrives.
+	service.ShutdownOnSignal(200 * time.Millisecond)
+
+	select {
+	case <-served:
+		t.Fatal("server stopped serving before any signal was sent")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatalf("failed to signal self: %v", err)
+	}
+
+	select {
+	case err := <-served:
+		if err != nil && err != http.ErrServerClosed {
+			t.Fatalf("Serve returned unexpected error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not shut down after SIGINT")
+	}
+}
@@ -0,0 +1,60 @@
+package restful
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+
+func TestSendResponseStream(t *testing.T) {
+	httpRequest := httptest.NewRequest("GET", "/", nil)
+	request := Request{RequestID: "test", HttpRequest: httpRequest}
+	recorder := httptest.NewRecorder()
+
+	response := Response{
+		Code: ScOK,
+		Stream: func(ctx context.Context, w io.Writer) (error) {
+			_, err := w.Write([]byte("hello"))
+			return err
+		},
+	}
+
+	SendResponse(request, recorder, response)
+
+	if recorder.Body.String() != "hello" {
+		t.Errorf("body = %q, want %q", recorder.Body.String(), "hello")
+	}
+}
+
+
+func TestEventStreamStopsOnCancellation(t *testing.T) {
+	ch := make(chan Event)
+	response := EventStream(ch)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- response.Stream(ctx, discardWriter{})
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("expected Stream to return the cancellation error, got nil")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Stream did not return after its context was cancelled")
+	}
+}
+
+
+type discardWriter struct{}
+
+func (discardWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}